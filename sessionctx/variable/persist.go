@@ -0,0 +1,166 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variable
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/types"
+)
+
+// Origin values reported by GetSysVarOrigin for
+// performance_schema.persisted_variables.
+const (
+	OriginCompiled  = "COMPILED"
+	OriginGlobal    = "GLOBAL"
+	OriginPersisted = "PERSISTED"
+)
+
+// sysVarOrigins records, for every global system variable that has been set
+// at least once, where its current in-memory value came from. SET
+// PERSIST_ONLY also records OriginPersisted, even though it leaves the
+// in-memory value unchanged. It backs the
+// performance_schema.persisted_variables virtual table.
+var sysVarOrigins = struct {
+	sync.RWMutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+func recordSysVarOrigin(name, origin string) {
+	sysVarOrigins.Lock()
+	sysVarOrigins.m[name] = origin
+	sysVarOrigins.Unlock()
+}
+
+// GetSysVarOrigin reports where the current value of a global system
+// variable came from: a persisted `SET PERSIST`, a plain `SET GLOBAL` that
+// has not been persisted, or the compiled-in default. After a SET
+// PERSIST_ONLY it reports OriginPersisted although the in-memory value is
+// still the one set before.
+func GetSysVarOrigin(name string) string {
+	name = strings.ToLower(name)
+	sysVarOrigins.RLock()
+	origin, ok := sysVarOrigins.m[name]
+	sysVarOrigins.RUnlock()
+	if ok {
+		return origin
+	}
+	return OriginCompiled
+}
+
+// SetGlobalSystemVar sets a global system variable's in-memory value for a
+// plain `SET GLOBAL`, without persisting it. This is the actual write path
+// that marks a variable's origin as OriginGlobal: GetGlobalSystemVar and
+// GetSessionSystemVar only ever read through to GlobalVarsAccessor, so a
+// variable that has merely been selected, and never explicitly set, keeps
+// reporting OriginCompiled.
+func SetGlobalSystemVar(vars *SessionVars, name string, value types.Datum) error {
+	name = strings.ToLower(name)
+	sysVar := SysVars[name]
+	if sysVar == nil {
+		return UnknownSystemVar
+	}
+	if sysVar.Scope == ScopeSession {
+		return ErrIncorrectScope.GenByArgs(name, "GLOBAL")
+	}
+	sVal, err := value.ToString()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sVal, err = ValidateSetSystemVar(vars, name, sVal)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := setGlobalSysVarAndNotify(vars, name, sVal); err != nil {
+		return errors.Trace(err)
+	}
+	recordSysVarOrigin(name, OriginGlobal)
+	return nil
+}
+
+// setGlobalSysVarAndNotify writes the global value through
+// vars.GlobalVarsAccessor and fires any watchers registered with
+// RegisterSysVarWatcher, comparing against the value seen just before the
+// write. Both SetGlobalSystemVar and the non-PERSIST_ONLY path of
+// SetPersistSystemVar funnel through here so the two never drift.
+func setGlobalSysVarAndNotify(vars *SessionVars, name, sVal string) error {
+	oldVal, err := vars.GlobalVarsAccessor.GetGlobalSysVar(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := vars.GlobalVarsAccessor.SetGlobalSysVar(name, sVal); err != nil {
+		return errors.Trace(err)
+	}
+	fireSysVarWatchers(name, oldVal, sVal)
+	return nil
+}
+
+// SetPersistSystemVar sets a global system variable and durably persists it,
+// implementing MySQL 8.0's `SET PERSIST` (onlyPersist is false) and
+// `SET PERSIST_ONLY` (onlyPersist is true) semantics. The persisted value is
+// written through vars.GlobalVarsAccessor so that LoadPersistedSysVars can
+// re-apply it the next time the cluster bootstraps; PERSIST_ONLY stops short
+// of touching the in-memory global value, matching MySQL's behavior for
+// variables that cannot be changed while the server is running.
+func SetPersistSystemVar(vars *SessionVars, name string, value types.Datum, onlyPersist bool) error {
+	name = strings.ToLower(name)
+	sysVar := SysVars[name]
+	if sysVar == nil {
+		return UnknownSystemVar
+	}
+	if sysVar.Scope == ScopeSession {
+		return ErrIncorrectScope.GenByArgs(name, "GLOBAL")
+	}
+	sVal, err := value.ToString()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sVal, err = ValidateSetSystemVar(vars, name, sVal)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := vars.GlobalVarsAccessor.SetPersistSysVar(name, sVal); err != nil {
+		return errors.Trace(err)
+	}
+	recordSysVarOrigin(name, OriginPersisted)
+	if onlyPersist {
+		return nil
+	}
+	return errors.Trace(setGlobalSysVarAndNotify(vars, name, sVal))
+}
+
+// LoadPersistedSysVars loads every persisted global system variable from the
+// durable store and applies it to the in-memory global value. It is meant to
+// be called once by the session manager during startup, before the server
+// accepts connections, so that values set with SET PERSIST survive a cluster
+// restart.
+func LoadPersistedSysVars(accessor GlobalVarsAccessor) error {
+	persisted, err := accessor.GetAllPersistedSysVars()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for name, sVal := range persisted {
+		name = strings.ToLower(name)
+		if SysVars[name] == nil {
+			continue
+		}
+		if err := accessor.SetGlobalSysVar(name, sVal); err != nil {
+			return errors.Trace(err)
+		}
+		recordSysVarOrigin(name, OriginPersisted)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variable
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/types"
+)
+
+// newPersistTestSysVar registers a throwaway global-scope SysVar under name
+// and returns a func that removes it again, so tests can exercise
+// SetGlobalSystemVar/SetPersistSystemVar/LoadPersistedSysVars without
+// depending on any particular variable already being declared in SysVars.
+func newPersistTestSysVar(name string) func() {
+	SysVars[name] = &SysVar{Scope: ScopeGlobal, Name: name, Value: "0"}
+	return func() { delete(SysVars, name) }
+}
+
+func TestGetSysVarOriginDefaultsToCompiled(t *testing.T) {
+	name := "persist_test_untouched_var"
+	if origin := GetSysVarOrigin(name); origin != OriginCompiled {
+		t.Fatalf("got origin %q for a variable that was never set, want %q", origin, OriginCompiled)
+	}
+}
+
+func TestRecordSysVarOrigin(t *testing.T) {
+	name := "persist_test_tracked_var"
+	recordSysVarOrigin(name, OriginGlobal)
+	if origin := GetSysVarOrigin(name); origin != OriginGlobal {
+		t.Fatalf("got origin %q after recording %q, want %q", origin, OriginGlobal, OriginGlobal)
+	}
+	recordSysVarOrigin(name, OriginPersisted)
+	if origin := GetSysVarOrigin(name); origin != OriginPersisted {
+		t.Fatalf("got origin %q after overwriting with %q, want %q", origin, OriginPersisted, OriginPersisted)
+	}
+}
+
+func TestGetSysVarOriginIsCaseInsensitive(t *testing.T) {
+	recordSysVarOrigin("persist_test_case_var", OriginPersisted)
+	if origin := GetSysVarOrigin("PERSIST_TEST_CASE_VAR"); origin != OriginPersisted {
+		t.Fatalf("got origin %q, want %q", origin, OriginPersisted)
+	}
+}
+
+func TestSetPersistSystemVarOnlyPersist(t *testing.T) {
+	const name = "persist_test_only_persist_var"
+	defer newPersistTestSysVar(name)()
+
+	accessor := newFakeGlobalVarsAccessor()
+	accessor.globals[name] = "0"
+	vars := &SessionVars{GlobalVarsAccessor: accessor}
+
+	if err := SetPersistSystemVar(vars, name, types.NewStringDatum("1"), true); err != nil {
+		t.Fatalf("SetPersistSystemVar: %v", err)
+	}
+	if got := accessor.globals[name]; got != "0" {
+		t.Fatalf("PERSIST_ONLY touched the in-memory global value: got %q, want unchanged %q", got, "0")
+	}
+	if got := accessor.persisted[name]; got != "1" {
+		t.Fatalf("got persisted value %q, want %q", got, "1")
+	}
+	if origin := GetSysVarOrigin(name); origin != OriginPersisted {
+		t.Fatalf("got origin %q, want %q", origin, OriginPersisted)
+	}
+}
+
+func TestSetPersistSystemVarAlsoUpdatesGlobal(t *testing.T) {
+	const name = "persist_test_persist_and_global_var"
+	defer newPersistTestSysVar(name)()
+
+	accessor := newFakeGlobalVarsAccessor()
+	accessor.globals[name] = "0"
+	vars := &SessionVars{GlobalVarsAccessor: accessor}
+
+	if err := SetPersistSystemVar(vars, name, types.NewStringDatum("1"), false); err != nil {
+		t.Fatalf("SetPersistSystemVar: %v", err)
+	}
+	if got := accessor.globals[name]; got != "1" {
+		t.Fatalf("got global value %q, want %q", got, "1")
+	}
+	if got := accessor.persisted[name]; got != "1" {
+		t.Fatalf("got persisted value %q, want %q", got, "1")
+	}
+}
+
+func TestSetGlobalSystemVarRecordsGlobalOrigin(t *testing.T) {
+	const name = "persist_test_global_only_var"
+	defer newPersistTestSysVar(name)()
+
+	accessor := newFakeGlobalVarsAccessor()
+	accessor.globals[name] = "0"
+	vars := &SessionVars{GlobalVarsAccessor: accessor}
+
+	if err := SetGlobalSystemVar(vars, name, types.NewStringDatum("1")); err != nil {
+		t.Fatalf("SetGlobalSystemVar: %v", err)
+	}
+	if got := accessor.globals[name]; got != "1" {
+		t.Fatalf("got global value %q, want %q", got, "1")
+	}
+	if origin := GetSysVarOrigin(name); origin != OriginGlobal {
+		t.Fatalf("got origin %q, want %q", origin, OriginGlobal)
+	}
+	if _, persisted := accessor.persisted[name]; persisted {
+		t.Fatalf("SetGlobalSystemVar must not write a persisted value")
+	}
+}
+
+func TestLoadPersistedSysVarsRoundTrip(t *testing.T) {
+	const name = "persist_test_load_var"
+	defer newPersistTestSysVar(name)()
+
+	accessor := newFakeGlobalVarsAccessor()
+	accessor.persisted[name] = "1"
+
+	if err := LoadPersistedSysVars(accessor); err != nil {
+		t.Fatalf("LoadPersistedSysVars: %v", err)
+	}
+	if got := accessor.globals[name]; got != "1" {
+		t.Fatalf("got global value %q after load, want %q", got, "1")
+	}
+	if origin := GetSysVarOrigin(name); origin != OriginPersisted {
+		t.Fatalf("got origin %q, want %q", origin, OriginPersisted)
+	}
+}
+
+func TestLoadPersistedSysVarsSkipsUnknownNames(t *testing.T) {
+	accessor := newFakeGlobalVarsAccessor()
+	accessor.persisted["persist_test_unknown_var"] = "1"
+
+	if err := LoadPersistedSysVars(accessor); err != nil {
+		t.Fatalf("LoadPersistedSysVars: %v", err)
+	}
+	if _, ok := accessor.globals["persist_test_unknown_var"]; ok {
+		t.Fatalf("LoadPersistedSysVars applied a value for a name absent from SysVars")
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variable
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SysVarValidator normalizes and validates a new value for a system
+// variable. It returns the value to store, which may differ from value
+// (e.g. after truncation or case normalization), or an error if the value
+// must be rejected outright. A validator may also call
+// vars.StmtCtx.AppendWarning to report a non-fatal truncation, mirroring
+// MySQL's behavior for out-of-range numeric SET statements.
+type SysVarValidator func(vars *SessionVars, name, value string) (string, error)
+
+// sysVarValidators holds the validator registered for each system variable,
+// keyed by lower-cased name. RegisterSysVarValidator populates it at
+// declaration time, so that ValidateSetSystemVar can dispatch to it instead
+// of growing a central switch statement. It is guarded by a mutex because
+// ValidateSetSystemVar reads it from every session executing SET, while
+// plugins may call RegisterSysVarValidator after startup.
+var sysVarValidators = struct {
+	sync.RWMutex
+	m map[string]SysVarValidator
+}{m: make(map[string]SysVarValidator)}
+
+// RegisterSysVarValidator registers the validator ValidateSetSystemVar uses
+// for the named system variable. Plugins and tests can call this to teach
+// ValidateSetSystemVar about a new variable without editing this package.
+func RegisterSysVarValidator(name string, validator SysVarValidator) {
+	sysVarValidators.Lock()
+	defer sysVarValidators.Unlock()
+	sysVarValidators.m[strings.ToLower(name)] = validator
+}
+
+// lookupSysVarValidator returns the validator registered for the (already
+// lower-cased) name, if any.
+func lookupSysVarValidator(name string) (SysVarValidator, bool) {
+	sysVarValidators.RLock()
+	defer sysVarValidators.RUnlock()
+	validator, ok := sysVarValidators.m[name]
+	return validator, ok
+}
+
+// ValidateBool returns a validator accepting MySQL's boolean spellings
+// ("ON"/"1" and "OFF"/"0") and normalizing them to "1"/"0".
+func ValidateBool() SysVarValidator {
+	return func(vars *SessionVars, name, value string) (string, error) {
+		if strings.EqualFold(value, "ON") || value == "1" {
+			return "1", nil
+		}
+		if strings.EqualFold(value, "OFF") || value == "0" {
+			return "0", nil
+		}
+		return value, ErrWrongValueForVar.GenByArgs(name, value)
+	}
+}
+
+// ValidateBoolKeepInput is like ValidateBool but echoes the original input
+// back unchanged instead of normalizing it to "1"/"0". It is used for
+// TiDB-specific knobs that other components read back as a raw string.
+func ValidateBoolKeepInput() SysVarValidator {
+	return func(vars *SessionVars, name, value string) (string, error) {
+		if TiDBOptOn(value) || strings.EqualFold(value, "OFF") || value == "0" {
+			return value, nil
+		}
+		return value, ErrWrongValueForVar.GenByArgs(name, value)
+	}
+}
+
+// ValidateEnum returns a validator accepting any of values case-insensitively,
+// or its 1-based ordinal as a string per MySQL's ENUM convention, normalizing
+// the result to its canonical spelling in values.
+func ValidateEnum(values ...string) SysVarValidator {
+	return func(vars *SessionVars, name, value string) (string, error) {
+		for i, v := range values {
+			if strings.EqualFold(value, v) || value == strconv.Itoa(i+1) {
+				return v, nil
+			}
+		}
+		return value, ErrWrongValueForVar.GenByArgs(name, value)
+	}
+}
+
+// ValidateIntRange returns a validator parsing value as a signed integer and
+// clamping it into [min, max], appending ErrTruncatedWrongValue as a warning
+// when clamping occurs instead of rejecting the statement.
+func ValidateIntRange(min, max int64) SysVarValidator {
+	return func(vars *SessionVars, name, value string) (string, error) {
+		val, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return value, ErrWrongTypeForVar.GenByArgs(name)
+		}
+		if val < min {
+			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
+			return strconv.FormatInt(min, 10), nil
+		}
+		if val > max {
+			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
+			return strconv.FormatInt(max, 10), nil
+		}
+		return value, nil
+	}
+}
+
+// ValidateUintRange is ValidateIntRange for unsigned ranges.
+func ValidateUintRange(min, max uint64) SysVarValidator {
+	return func(vars *SessionVars, name, value string) (string, error) {
+		val, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return value, ErrWrongTypeForVar.GenByArgs(name)
+		}
+		if val < min {
+			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
+			return strconv.FormatUint(min, 10), nil
+		}
+		if val > max {
+			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
+			return strconv.FormatUint(max, 10), nil
+		}
+		return value, nil
+	}
+}
+
+// ValidatePositiveInt returns a validator requiring value to parse as a
+// strictly positive int, rejecting anything else rather than clamping it.
+func ValidatePositiveInt() SysVarValidator {
+	return func(vars *SessionVars, name, value string) (string, error) {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return value, ErrWrongTypeForVar.GenByArgs(name)
+		}
+		if v <= 0 {
+			return value, ErrWrongValueForVar.GenByArgs(name, value)
+		}
+		return value, nil
+	}
+}
+
+// ValidateDuration returns a validator requiring value to parse as a signed
+// 64-bit integer, for variables expressed as a raw duration/count without a
+// further range restriction.
+func ValidateDuration() SysVarValidator {
+	return func(vars *SessionVars, name, value string) (string, error) {
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return value, ErrWrongValueForVar.GenByArgs(name)
+		}
+		return value, nil
+	}
+}
+
+// ValidateTimeZone returns a validator normalizing MySQL's "SYSTEM" spelling
+// and otherwise passing the value through for parseTimeZone to interpret.
+func ValidateTimeZone() SysVarValidator {
+	return func(vars *SessionVars, name, value string) (string, error) {
+		if strings.EqualFold(value, "SYSTEM") {
+			return "SYSTEM", nil
+		}
+		return value, nil
+	}
+}
+
+func validateReadOnly(vars *SessionVars, name, value string) (string, error) {
+	return value, ErrReadOnly.GenByArgs(name)
+}
+
+// validateDelayKeyWrite and validateSessionTrackGtids are hand-written
+// rather than built from ValidateEnum: their numeric aliases don't follow
+// ValidateEnum's 1-based-by-declaration-order convention (delay_key_write's
+// own values are declared "ON", "OFF", "ALL" but alias as OFF=0, ON=1,
+// ALL=2, matching MySQL's boolean convention plus ALL=2), so expressing them
+// through ValidateEnum would silently renumber them.
+func validateDelayKeyWrite(vars *SessionVars, name, value string) (string, error) {
+	switch {
+	case strings.EqualFold(value, "ON") || value == "1":
+		return "ON", nil
+	case strings.EqualFold(value, "OFF") || value == "0":
+		return "OFF", nil
+	case strings.EqualFold(value, "ALL") || value == "2":
+		return "ALL", nil
+	}
+	return value, ErrWrongValueForVar.GenByArgs(name, value)
+}
+
+func validateSessionTrackGtids(vars *SessionVars, name, value string) (string, error) {
+	switch {
+	case strings.EqualFold(value, "OFF") || value == "0":
+		return "OFF", nil
+	case strings.EqualFold(value, "OWN_GTID") || value == "1":
+		return "OWN_GTID", nil
+	case strings.EqualFold(value, "ALL_GTIDS") || value == "2":
+		return "ALL_GTIDS", nil
+	}
+	return value, ErrWrongValueForVar.GenByArgs(name, value)
+}
+
+func init() {
+	RegisterSysVarValidator(DefaultWeekFormat, ValidateIntRange(0, 7))
+	RegisterSysVarValidator(DelayKeyWrite, validateDelayKeyWrite)
+	RegisterSysVarValidator(FlushTime, ValidateIntRange(0, math.MaxInt64))
+	RegisterSysVarValidator(GroupConcatMaxLen, ValidateUintRange(4, 18446744073709551615))
+	RegisterSysVarValidator(InteractiveTimeout, ValidateIntRange(1, math.MaxInt64))
+	RegisterSysVarValidator(MaxConnections, ValidateIntRange(1, 100000))
+	RegisterSysVarValidator(MaxSortLength, ValidateIntRange(4, 8388608))
+	RegisterSysVarValidator(MaxSpRecursionDepth, ValidateIntRange(0, 255))
+	RegisterSysVarValidator(OldPasswords, ValidateIntRange(0, 2))
+	RegisterSysVarValidator(MaxUserConnections, ValidateUintRange(0, 4294967295))
+	RegisterSysVarValidator(SessionTrackGtids, validateSessionTrackGtids)
+	RegisterSysVarValidator(TimeZone, ValidateTimeZone())
+	RegisterSysVarValidator(WarningCount, validateReadOnly)
+	RegisterSysVarValidator(ErrorCount, validateReadOnly)
+
+	for _, name := range []string{
+		GeneralLog, AvoidTemporalUpgrade, BigTables, CheckProxyUsers, CoreFile, EndMakersInJSON,
+		SQLLogBin, OfflineMode, PseudoSlaveMode, LowPriorityUpdates, SkipNameResolve,
+		ForeignKeyChecks, SQLSafeUpdates,
+	} {
+		RegisterSysVarValidator(name, ValidateBool())
+	}
+
+	for _, name := range []string{
+		AutocommitVar, TiDBImportingData, TiDBSkipUTF8Check, TiDBOptAggPushDown,
+		TiDBOptInSubqUnFolding, TiDBEnableTablePartition, TiDBBatchInsert,
+		TiDBDisableTxnAutoRetry, TiDBEnableStreaming, TiDBBatchDelete,
+	} {
+		RegisterSysVarValidator(name, ValidateBoolKeepInput())
+	}
+
+	for _, name := range []string{
+		TiDBIndexLookupConcurrency, TiDBIndexLookupJoinConcurrency, TiDBIndexJoinBatchSize,
+		TiDBIndexLookupSize, TiDBHashJoinConcurrency, TiDBHashAggPartialConcurrency,
+		TiDBHashAggFinalConcurrency, TiDBDistSQLScanConcurrency, TiDBIndexSerialScanConcurrency,
+		TiDBDDLReorgWorkerCount, TiDBBackoffLockFast, TiDBMaxChunkSize, TiDBDMLBatchSize,
+		TiDBOptimizerSelectivityLevel, TiDBGeneralLog,
+	} {
+		RegisterSysVarValidator(name, ValidatePositiveInt())
+	}
+
+	for _, name := range []string{
+		TiDBProjectionConcurrency, TIDBMemQuotaQuery, TIDBMemQuotaHashJoin, TIDBMemQuotaMergeJoin,
+		TIDBMemQuotaSort, TIDBMemQuotaTopn, TIDBMemQuotaIndexLookupReader, TIDBMemQuotaIndexLookupJoin,
+		TIDBMemQuotaNestedLoopApply, TiDBRetryLimit,
+	} {
+		RegisterSysVarValidator(name, ValidateDuration())
+	}
+}
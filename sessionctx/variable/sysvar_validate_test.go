@@ -0,0 +1,150 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variable
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestDelayKeyWriteNumericAliases locks in the numeric mappings the old
+// switch statement in ValidateSetSystemVar used for delay_key_write: the
+// aliases don't follow ValidateEnum's declaration-order numbering, which is
+// exactly what made the ValidateEnum-based migration regress this variable.
+func TestDelayKeyWriteNumericAliases(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"0", "OFF", false},
+		{"1", "ON", false},
+		{"2", "ALL", false},
+		{"on", "ON", false},
+		{"off", "OFF", false},
+		{"all", "ALL", false},
+		{"3", "", true},
+	}
+	for _, tc := range cases {
+		got, err := validateDelayKeyWrite(nil, "delay_key_write", tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("validateDelayKeyWrite(%q): got nil error, want one", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("validateDelayKeyWrite(%q): unexpected error %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("validateDelayKeyWrite(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestSessionTrackGtidsNumericAliases is the session_track_gtids analogue of
+// TestDelayKeyWriteNumericAliases.
+func TestSessionTrackGtidsNumericAliases(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"0", "OFF", false},
+		{"1", "OWN_GTID", false},
+		{"2", "ALL_GTIDS", false},
+		{"own_gtid", "OWN_GTID", false},
+		{"3", "", true},
+	}
+	for _, tc := range cases {
+		got, err := validateSessionTrackGtids(nil, "session_track_gtids", tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("validateSessionTrackGtids(%q): got nil error, want one", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("validateSessionTrackGtids(%q): unexpected error %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("validateSessionTrackGtids(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestValidateEnumIsOneBased documents ValidateEnum's own ordinal
+// convention (distinct from the hand-rolled validators above) so a future
+// caller doesn't assume it matches delay_key_write/session_track_gtids.
+func TestValidateEnumIsOneBased(t *testing.T) {
+	validator := ValidateEnum("FIRST", "SECOND", "THIRD")
+	cases := map[string]string{
+		"1":      "FIRST",
+		"2":      "SECOND",
+		"3":      "THIRD",
+		"second": "SECOND",
+	}
+	for in, want := range cases {
+		got, err := validator(nil, "enum_test_var", in)
+		if err != nil {
+			t.Errorf("ValidateEnum(...)( %q): unexpected error %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ValidateEnum(...)( %q) = %q, want %q", in, got, want)
+		}
+	}
+	if _, err := validator(nil, "enum_test_var", "0"); err == nil {
+		t.Errorf("ValidateEnum(...)( %q): got nil error, want one", "0")
+	}
+}
+
+func TestValidateBool(t *testing.T) {
+	validator := ValidateBool()
+	for _, in := range []string{"ON", "on", "1"} {
+		if got, err := validator(nil, "v", in); err != nil || got != "1" {
+			t.Errorf("ValidateBool()(%q) = (%q, %v), want (\"1\", nil)", in, got, err)
+		}
+	}
+	for _, in := range []string{"OFF", "off", "0"} {
+		if got, err := validator(nil, "v", in); err != nil || got != "0" {
+			t.Errorf("ValidateBool()(%q) = (%q, %v), want (\"0\", nil)", in, got, err)
+		}
+	}
+	if _, err := validator(nil, "v", "maybe"); err == nil {
+		t.Errorf("ValidateBool()(%q): got nil error, want one", "maybe")
+	}
+}
+
+// TestRegisterSysVarValidatorConcurrent exercises RegisterSysVarValidator and
+// lookupSysVarValidator concurrently under -race: before sysVarValidators was
+// guarded by a mutex this was an unsynchronized concurrent map read/write,
+// which crashes the process rather than merely racing benignly.
+func TestRegisterSysVarValidatorConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			RegisterSysVarValidator("concurrent_test_var", ValidateBool())
+		}(i)
+		go func() {
+			defer wg.Done()
+			lookupSysVarValidator("concurrent_test_var")
+		}()
+	}
+	wg.Wait()
+}
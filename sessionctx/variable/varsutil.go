@@ -170,190 +170,11 @@ func ValidateSetSystemVar(vars *SessionVars, name string, value string) (string,
 		}
 		return value, UnknownSystemVar.GenByArgs(name)
 	}
-	switch name {
-	case DefaultWeekFormat:
-		val, err := strconv.Atoi(value)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if val < 0 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "0", nil
-		}
-		if val > 7 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "7", nil
-		}
-	case DelayKeyWrite:
-		if strings.EqualFold(value, "ON") || value == "1" {
-			return "ON", nil
-		} else if strings.EqualFold(value, "OFF") || value == "0" {
-			return "OFF", nil
-		} else if strings.EqualFold(value, "ALL") || value == "2" {
-			return "ALL", nil
-		}
-		return value, ErrWrongValueForVar.GenByArgs(name, value)
-	case FlushTime:
-		val, err := strconv.Atoi(value)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if val < 0 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "0", nil
-		}
-	case GroupConcatMaxLen:
-		val, err := strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if val < 4 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "4", nil
-		}
-		if val > 18446744073709551615 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "18446744073709551615", nil
-		}
-	case InteractiveTimeout:
-		val, err := strconv.Atoi(value)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if val < 1 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "1", nil
-		}
-	case MaxConnections:
-		val, err := strconv.Atoi(value)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if val < 1 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "1", nil
-		}
-		if val > 100000 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "100000", nil
-		}
-	case MaxSortLength:
-		val, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if val < 4 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "4", nil
-		}
-		if val > 8388608 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "8388608", nil
-		}
-	case MaxSpRecursionDepth:
-		val, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if val < 0 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "0", nil
-		}
-		if val > 255 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "255", nil
-		}
-	case OldPasswords:
-		val, err := strconv.Atoi(value)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if val < 0 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "0", nil
-		}
-		if val > 2 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "2", nil
-		}
-	case MaxUserConnections:
-		val, err := strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if val < 0 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "0", nil
-		}
-		if val > 4294967295 {
-			vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
-			return "4294967295", nil
-		}
-	case SessionTrackGtids:
-		if strings.EqualFold(value, "OFF") || value == "0" {
-			return "OFF", nil
-		} else if strings.EqualFold(value, "OWN_GTID") || value == "1" {
-			return "OWN_GTID", nil
-		} else if strings.EqualFold(value, "ALL_GTIDS") || value == "2" {
-			return "ALL_GTIDS", nil
-		}
-		return value, ErrWrongValueForVar.GenByArgs(name, value)
-	case TimeZone:
-		if strings.EqualFold(value, "SYSTEM") {
-			return "SYSTEM", nil
-		}
-		return value, nil
-	case WarningCount, ErrorCount:
-		return value, ErrReadOnly.GenByArgs(name)
-	case GeneralLog, AvoidTemporalUpgrade, BigTables, CheckProxyUsers, CoreFile, EndMakersInJSON, SQLLogBin, OfflineMode,
-		PseudoSlaveMode, LowPriorityUpdates, SkipNameResolve, ForeignKeyChecks, SQLSafeUpdates:
-		if strings.EqualFold(value, "ON") || value == "1" {
-			return "1", nil
-		} else if strings.EqualFold(value, "OFF") || value == "0" {
-			return "0", nil
-		}
-		return value, ErrWrongValueForVar.GenByArgs(name, value)
-	case AutocommitVar, TiDBImportingData, TiDBSkipUTF8Check, TiDBOptAggPushDown,
-		TiDBOptInSubqUnFolding, TiDBEnableTablePartition,
-		TiDBBatchInsert, TiDBDisableTxnAutoRetry, TiDBEnableStreaming,
-		TiDBBatchDelete:
-		if strings.EqualFold(value, "ON") || value == "1" || strings.EqualFold(value, "OFF") || value == "0" {
-			return value, nil
-		}
-		return value, ErrWrongValueForVar.GenByArgs(name, value)
-	case TiDBIndexLookupConcurrency, TiDBIndexLookupJoinConcurrency, TiDBIndexJoinBatchSize,
-		TiDBIndexLookupSize,
-		TiDBHashJoinConcurrency,
-		TiDBHashAggPartialConcurrency,
-		TiDBHashAggFinalConcurrency,
-		TiDBDistSQLScanConcurrency,
-		TiDBIndexSerialScanConcurrency, TiDBDDLReorgWorkerCount,
-		TiDBBackoffLockFast, TiDBMaxChunkSize,
-		TiDBDMLBatchSize, TiDBOptimizerSelectivityLevel,
-		TiDBGeneralLog:
-		v, err := strconv.Atoi(value)
-		if err != nil {
-			return value, ErrWrongTypeForVar.GenByArgs(name)
-		}
-		if v <= 0 {
-			return value, ErrWrongValueForVar.GenByArgs(name, value)
-		}
-		return value, nil
-	case TiDBProjectionConcurrency,
-		TIDBMemQuotaQuery,
-		TIDBMemQuotaHashJoin,
-		TIDBMemQuotaMergeJoin,
-		TIDBMemQuotaSort,
-		TIDBMemQuotaTopn,
-		TIDBMemQuotaIndexLookupReader,
-		TIDBMemQuotaIndexLookupJoin,
-		TIDBMemQuotaNestedLoopApply,
-		TiDBRetryLimit:
-		_, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return value, ErrWrongValueForVar.GenByArgs(name)
-		}
-		return value, nil
+	// Variables register their own validator via RegisterSysVarValidator at
+	// declaration time instead of adding a case here; this just dispatches
+	// to it. Variables with no registered validator accept any value as-is.
+	if validator, ok := lookupSysVarValidator(strings.ToLower(name)); ok {
+		return validator(vars, name, value)
 	}
 	return value, nil
 }
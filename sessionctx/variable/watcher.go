@@ -0,0 +1,161 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variable
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// defaultSysVarWatcherInterval is how often SysVarChangeNotifier polls
+// GlobalVarsAccessor for changes made by SET GLOBAL on other TiDB nodes.
+const defaultSysVarWatcherInterval = 2 * time.Second
+
+var sysVarWatchers = struct {
+	sync.RWMutex
+	fns map[string][]func(old, new string)
+}{fns: make(map[string][]func(old, new string))}
+
+// RegisterSysVarWatcher registers fn to be called whenever the global value
+// of the named system variable changes, whether the change was made on this
+// node via SET GLOBAL / SET PERSIST or observed on a remote node by
+// SysVarChangeNotifier. It replaces the previous pattern of components
+// maintaining their own atomic copy of a global knob (e.g.
+// SetDDLReorgWorkerCounter, ProcessGeneralLog) and polling it independently.
+func RegisterSysVarWatcher(name string, fn func(old, new string)) {
+	name = strings.ToLower(name)
+	sysVarWatchers.Lock()
+	defer sysVarWatchers.Unlock()
+	sysVarWatchers.fns[name] = append(sysVarWatchers.fns[name], fn)
+}
+
+func fireSysVarWatchers(name, oldVal, newVal string) {
+	if oldVal == newVal {
+		return
+	}
+	name = strings.ToLower(name)
+	sysVarWatchers.RLock()
+	fns := sysVarWatchers.fns[name]
+	sysVarWatchers.RUnlock()
+	for _, fn := range fns {
+		fn(oldVal, newVal)
+	}
+}
+
+// watchedSysVarNames returns the names that currently have at least one
+// registered watcher, so SysVarChangeNotifier only has to poll those.
+func watchedSysVarNames() []string {
+	sysVarWatchers.RLock()
+	defer sysVarWatchers.RUnlock()
+	names := make([]string, 0, len(sysVarWatchers.fns))
+	for name := range sysVarWatchers.fns {
+		names = append(names, name)
+	}
+	return names
+}
+
+// SysVarChangeNotifier periodically polls GlobalVarsAccessor for global
+// system variables that have registered watchers, so that a SET GLOBAL or
+// SET PERSIST executed on one TiDB node is observed and applied by every
+// other node in the cluster without a restart. In deployments backed by
+// PD/etcd this polling loop can be swapped for a watch on the corresponding
+// key without changing RegisterSysVarWatcher callers.
+type SysVarChangeNotifier struct {
+	accessor GlobalVarsAccessor
+	interval time.Duration
+	last     map[string]string
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewSysVarChangeNotifier creates a notifier that polls accessor every
+// interval. A non-positive interval defaults to defaultSysVarWatcherInterval.
+func NewSysVarChangeNotifier(accessor GlobalVarsAccessor, interval time.Duration) *SysVarChangeNotifier {
+	if interval <= 0 {
+		interval = defaultSysVarWatcherInterval
+	}
+	return &SysVarChangeNotifier{
+		accessor: accessor,
+		interval: interval,
+		last:     make(map[string]string),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start begins polling in a background goroutine. Call Stop to shut it down.
+func (n *SysVarChangeNotifier) Start() {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		ticker := time.NewTicker(n.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				n.poll()
+			case <-n.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop terminates the polling goroutine and waits for it to exit.
+func (n *SysVarChangeNotifier) Stop() {
+	close(n.stop)
+	n.wg.Wait()
+}
+
+func (n *SysVarChangeNotifier) poll() {
+	for _, name := range watchedSysVarNames() {
+		newVal, err := n.accessor.GetGlobalSysVar(name)
+		if err != nil {
+			log.Warn("sysvar watcher failed to poll global variable, change propagation is stalled for it",
+				zap.String("name", name), zap.Error(err))
+			continue
+		}
+		oldVal, ok := n.last[name]
+		n.last[name] = newVal
+		if ok {
+			fireSysVarWatchers(name, oldVal, newVal)
+		}
+	}
+}
+
+func init() {
+	RegisterSysVarWatcher(TiDBDDLReorgWorkerCount, func(old, new string) {
+		v, err := strconv.Atoi(new)
+		if err != nil {
+			return
+		}
+		SetDDLReorgWorkerCounter(int32(v))
+	})
+	RegisterSysVarWatcher(TiDBGeneralLog, func(old, new string) {
+		v, err := strconv.Atoi(new)
+		if err != nil {
+			return
+		}
+		if v != 0 {
+			atomic.StoreUint32(&ProcessGeneralLog, 1)
+		} else {
+			atomic.StoreUint32(&ProcessGeneralLog, 0)
+		}
+	})
+}
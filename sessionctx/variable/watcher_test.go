@@ -0,0 +1,132 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variable
+
+import (
+	"sync"
+	"testing"
+)
+
+// fakeGlobalVarsAccessor is a minimal in-memory GlobalVarsAccessor for
+// tests that don't need a real store backing global/persisted variables.
+type fakeGlobalVarsAccessor struct {
+	mu        sync.Mutex
+	globals   map[string]string
+	persisted map[string]string
+}
+
+func newFakeGlobalVarsAccessor() *fakeGlobalVarsAccessor {
+	return &fakeGlobalVarsAccessor{
+		globals:   make(map[string]string),
+		persisted: make(map[string]string),
+	}
+}
+
+func (f *fakeGlobalVarsAccessor) GetAllSysVars() (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string]string, len(f.globals))
+	for k, v := range f.globals {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (f *fakeGlobalVarsAccessor) GetGlobalSysVar(name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.globals[name], nil
+}
+
+func (f *fakeGlobalVarsAccessor) SetGlobalSysVar(name, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.globals[name] = value
+	return nil
+}
+
+func (f *fakeGlobalVarsAccessor) GetAllPersistedSysVars() (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string]string, len(f.persisted))
+	for k, v := range f.persisted {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (f *fakeGlobalVarsAccessor) SetPersistSysVar(name, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.persisted[name] = value
+	return nil
+}
+
+func TestFireSysVarWatchers(t *testing.T) {
+	var gotOld, gotNew string
+	calls := 0
+	RegisterSysVarWatcher("watcher_test_var", func(old, new string) {
+		calls++
+		gotOld, gotNew = old, new
+	})
+
+	fireSysVarWatchers("WATCHER_TEST_VAR", "0", "1")
+	if calls != 1 {
+		t.Fatalf("got %d calls, want 1", calls)
+	}
+	if gotOld != "0" || gotNew != "1" {
+		t.Fatalf("got (%q, %q), want (\"0\", \"1\")", gotOld, gotNew)
+	}
+
+	fireSysVarWatchers("watcher_test_var", "1", "1")
+	if calls != 1 {
+		t.Fatalf("fireSysVarWatchers fired for an unchanged value: got %d calls, want 1", calls)
+	}
+}
+
+func TestSysVarChangeNotifierPoll(t *testing.T) {
+	accessor := newFakeGlobalVarsAccessor()
+	accessor.globals["poll_test_var"] = "10"
+
+	var seenOld, seenNew string
+	calls := 0
+	RegisterSysVarWatcher("poll_test_var", func(old, new string) {
+		calls++
+		seenOld, seenNew = old, new
+	})
+
+	notifier := NewSysVarChangeNotifier(accessor, 0)
+
+	// The first poll only establishes a baseline; it must not fire, since
+	// there is no "old" value yet to compare against.
+	notifier.poll()
+	if calls != 0 {
+		t.Fatalf("first poll fired %d times, want 0 (no baseline yet)", calls)
+	}
+
+	accessor.SetGlobalSysVar("poll_test_var", "20")
+	notifier.poll()
+	if calls != 1 {
+		t.Fatalf("second poll fired %d times, want 1", calls)
+	}
+	if seenOld != "10" || seenNew != "20" {
+		t.Fatalf("got (%q, %q), want (\"10\", \"20\")", seenOld, seenNew)
+	}
+
+	// Polling again with no change must not re-fire.
+	notifier.poll()
+	if calls != 1 {
+		t.Fatalf("third poll fired %d times, want 1 (value unchanged)", calls)
+	}
+}